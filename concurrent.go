@@ -0,0 +1,246 @@
+package orderedmap
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultShardCount is the number of shards ConcurrentOrderedMap uses
+// when WithShardCount is not given.
+const defaultShardCount = 32
+
+// ConcurrentOrderedMap is a concurrency-safe sibling of OrderedMap. It
+// shards keys across a fixed number of independently-locked buckets, so
+// that operations on unrelated keys do not contend on the same lock.
+//
+// Sharding means there is no single list that reflects the order every
+// key was inserted in, the way there is for OrderedMap: each shard only
+// remembers the order of the keys hashed to it. Use IterShard to range
+// over elements shard by shard without taking a global lock, or
+// IterOrdered to pay for a brief global lock in exchange for a merged
+// view in true insertion order.
+type ConcurrentOrderedMap[K comparable, V any] struct {
+	// seq must stay the first field: sync/atomic requires 8-byte
+	// alignment for 64-bit atomics, which is only guaranteed on 32-bit
+	// platforms for the first word of an allocated struct.
+	seq    int64
+	shards []*comapShard[K, V]
+}
+
+type comapShard[K comparable, V any] struct {
+	mu     sync.RWMutex
+	mapper map[K]*list.Element
+	lister *list.List
+}
+
+// comapElemVal is the per-element payload stored in a shard's list. seq
+// is a map-wide, monotonically increasing insertion sequence number used
+// to reconstruct global order in IterOrdered.
+type comapElemVal[K comparable, V any] struct {
+	Key   K
+	Value V
+	seq   int64
+}
+
+// ConcurrentOption configures NewConcurrent.
+type ConcurrentOption func(*concurrentOptions)
+
+type concurrentOptions struct {
+	shardCount int
+}
+
+// WithShardCount overrides the default shard count (32).
+func WithShardCount(n int) ConcurrentOption {
+	return func(o *concurrentOptions) { o.shardCount = n }
+}
+
+// NewConcurrent returns an instance of ConcurrentOrderedMap keyed by K
+// and valued by V.
+func NewConcurrent[K comparable, V any](opts ...ConcurrentOption) *ConcurrentOrderedMap[K, V] {
+	cfg := concurrentOptions{shardCount: defaultShardCount}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.shardCount <= 0 {
+		cfg.shardCount = defaultShardCount
+	}
+
+	shards := make([]*comapShard[K, V], cfg.shardCount)
+	for i := range shards {
+		shards[i] = &comapShard[K, V]{
+			mapper: make(map[K]*list.Element),
+			lister: list.New(),
+		}
+	}
+	return &ConcurrentOrderedMap[K, V]{shards: shards}
+}
+
+// shardFor returns the shard key is hashed to.
+func (m *ConcurrentOrderedMap[K, V]) shardFor(key K) *comapShard[K, V] {
+	h := fnv.New32a()
+	fmt.Fprint(h, key)
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// Set sets the given value under the specified key.
+func (m *ConcurrentOrderedMap[K, V]) Set(key K, value V) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, exist := shard.mapper[key]
+	if !exist {
+		shard.mapper[key] = shard.lister.PushBack(&comapElemVal[K, V]{Key: key, Value: value, seq: m.nextSeq()})
+	} else {
+		elem.Value.(*comapElemVal[K, V]).Value = value
+	}
+}
+
+// SetIfAbsent sets value under key only if key is not already present,
+// reporting whether it did so.
+func (m *ConcurrentOrderedMap[K, V]) SetIfAbsent(key K, value V) bool {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, exist := shard.mapper[key]; exist {
+		return false
+	}
+
+	shard.mapper[key] = shard.lister.PushBack(&comapElemVal[K, V]{Key: key, Value: value, seq: m.nextSeq()})
+	return true
+}
+
+// Get retrieves a value from the map under the given key. If no value
+// was associated with the given key, will return false.
+func (m *ConcurrentOrderedMap[K, V]) Get(key K) (V, bool) {
+	shard := m.shardFor(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	elem, exist := shard.mapper[key]
+	if !exist {
+		var zero V
+		return zero, false
+	}
+	return elem.Value.(*comapElemVal[K, V]).Value, true
+}
+
+// Delete deletes an item from the map.
+func (m *ConcurrentOrderedMap[K, V]) Delete(key K) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, exist := shard.mapper[key]
+	if !exist {
+		return
+	}
+
+	shard.lister.Remove(elem)
+	delete(shard.mapper, key)
+}
+
+// Upsert atomically inserts or updates the value under key: fn is
+// called with the current value and whether key already existed, and
+// its result becomes the new value.
+func (m *ConcurrentOrderedMap[K, V]) Upsert(key K, fn func(exist bool, current V) V) V {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, exist := shard.mapper[key]
+	if !exist {
+		var zero V
+		value := fn(false, zero)
+		shard.mapper[key] = shard.lister.PushBack(&comapElemVal[K, V]{Key: key, Value: value, seq: m.nextSeq()})
+		return value
+	}
+
+	ev := elem.Value.(*comapElemVal[K, V])
+	ev.Value = fn(true, ev.Value)
+	return ev.Value
+}
+
+// Count returns the total number of elements across all shards.
+func (m *ConcurrentOrderedMap[K, V]) Count() int {
+	total := 0
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		total += shard.lister.Len()
+		shard.mu.RUnlock()
+	}
+	return total
+}
+
+func (m *ConcurrentOrderedMap[K, V]) nextSeq() int64 {
+	return atomic.AddInt64(&m.seq, 1)
+}
+
+// IterShard calls fn once for every element, shard by shard, in each
+// shard's own insertion order. Only one shard is locked at a time, so
+// elements from different shards are not interleaved in any particular
+// global order. fn may return false to stop iteration early.
+func (m *ConcurrentOrderedMap[K, V]) IterShard(fn func(key K, value V) bool) {
+	for _, shard := range m.shards {
+		if !shard.iterate(fn) {
+			return
+		}
+	}
+}
+
+func (s *comapShard[K, V]) iterate(fn func(key K, value V) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for e := s.lister.Front(); e != nil; e = e.Next() {
+		ev := e.Value.(*comapElemVal[K, V])
+		if !fn(ev.Key, ev.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// IterOrdered calls fn once for each element in the order keys were
+// originally inserted across the whole map, by briefly locking every
+// shard and merging their lists using each element's insertion sequence
+// number. This is more expensive than IterShard and should only be used
+// when a single global order is actually needed. fn may return false to
+// stop iteration early.
+func (m *ConcurrentOrderedMap[K, V]) IterOrdered(fn func(key K, value V) bool) {
+	for _, ev := range m.snapshotOrdered() {
+		if !fn(ev.Key, ev.Value) {
+			return
+		}
+	}
+}
+
+// snapshotOrdered copies every element's Key/Value/seq into a plain value
+// slice while each shard's lock is held, rather than retaining the
+// shard's *comapElemVal pointers past the unlock: Set and Upsert mutate
+// that value in place under the shard lock, so reading through a
+// retained pointer after unlocking would race with them.
+func (m *ConcurrentOrderedMap[K, V]) snapshotOrdered() []comapElemVal[K, V] {
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+	}
+
+	var all []comapElemVal[K, V]
+	for _, shard := range m.shards {
+		for e := shard.lister.Front(); e != nil; e = e.Next() {
+			all = append(all, *e.Value.(*comapElemVal[K, V]))
+		}
+	}
+
+	for _, shard := range m.shards {
+		shard.mu.RUnlock()
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].seq < all[j].seq })
+	return all
+}
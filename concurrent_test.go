@@ -0,0 +1,160 @@
+package orderedmap
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSetGetDelete(t *testing.T) {
+	m := NewConcurrent[string, int]()
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+
+	if got, _ := m.Get("a"); got != 1 {
+		t.Errorf("Get(a) = %d, want 1", got)
+	}
+
+	if m.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", m.Count())
+	}
+
+	m.Delete("a")
+	if _, exist := m.Get("a"); exist {
+		t.Error("expected \"a\" to be deleted.")
+	}
+	if m.Count() != 1 {
+		t.Errorf("Count() = %d, want 1", m.Count())
+	}
+}
+
+func TestConcurrentSetIfAbsent(t *testing.T) {
+	m := NewConcurrent[string, int]()
+
+	if !m.SetIfAbsent("a", 1) {
+		t.Error("expected SetIfAbsent to set a new key.")
+	}
+	if m.SetIfAbsent("a", 2) {
+		t.Error("expected SetIfAbsent to report false for an existing key.")
+	}
+
+	val, _ := m.Get("a")
+	if val != 1 {
+		t.Errorf("Get(a) = %d, want 1 (unchanged).", val)
+	}
+}
+
+func TestConcurrentUpsert(t *testing.T) {
+	m := NewConcurrent[string, int]()
+
+	result := m.Upsert("a", func(exist bool, current int) int {
+		if exist {
+			t.Error("expected key to not exist yet.")
+		}
+		return current + 1
+	})
+	if result != 1 {
+		t.Errorf("Upsert result = %d, want 1", result)
+	}
+
+	result = m.Upsert("a", func(exist bool, current int) int {
+		if !exist {
+			t.Error("expected key to already exist.")
+		}
+		return current + 1
+	})
+	if result != 2 {
+		t.Errorf("Upsert result = %d, want 2", result)
+	}
+}
+
+func TestConcurrentIterShardCoversAllElements(t *testing.T) {
+	m := NewConcurrent[string, int]()
+	for i := 0; i < 200; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	seen := make(map[string]bool)
+	m.IterShard(func(key string, value int) bool {
+		seen[key] = true
+		return true
+	})
+
+	if len(seen) != 200 {
+		t.Errorf("IterShard visited %d elements, want 200", len(seen))
+	}
+}
+
+func TestConcurrentIterOrderedMatchesInsertionOrder(t *testing.T) {
+	m := NewConcurrent[string, int]()
+	for i := 0; i < 100; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	var keys []string
+	m.IterOrdered(func(key string, value int) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	if len(keys) != 100 {
+		t.Fatalf("IterOrdered visited %d elements, want 100", len(keys))
+	}
+	for i, key := range keys {
+		if key != strconv.Itoa(i) {
+			t.Errorf("key at position %d = %s, want %s", i, key, strconv.Itoa(i))
+		}
+	}
+}
+
+func TestConcurrentSafeForConcurrentUse(t *testing.T) {
+	m := NewConcurrent[int, int]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(i, i)
+			m.Get(i)
+		}(i)
+	}
+	wg.Wait()
+
+	if m.Count() != 50 {
+		t.Errorf("Count() = %d, want 50", m.Count())
+	}
+}
+
+func TestConcurrentIterOrderedRaceWithSet(t *testing.T) {
+	m := NewConcurrent[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			m.Set(i, i+1)
+			m.Upsert(i, func(_ bool, current int) int { return current + 1 })
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			m.IterOrdered(func(key, value int) bool { return true })
+		}
+	}()
+	wg.Wait()
+}
+
+func TestConcurrentWithShardCount(t *testing.T) {
+	m := NewConcurrent[string, int](WithShardCount(4))
+
+	if len(m.shards) != 4 {
+		t.Errorf("got %d shards, want 4", len(m.shards))
+	}
+}
@@ -0,0 +1,112 @@
+package orderedmap
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestIterate(t *testing.T) {
+	m := New[string, int]()
+	for i := 0; i < 10; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	var keys []string
+	m.Iterate(func(key string, value int) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	if len(keys) != 10 {
+		t.Fatalf("got %d keys, want 10", len(keys))
+	}
+	for i, key := range keys {
+		if key != strconv.Itoa(i) {
+			t.Errorf("key at position %d = %s, want %s", i, key, strconv.Itoa(i))
+		}
+	}
+}
+
+func TestIterateStopsEarly(t *testing.T) {
+	m := New[string, int]()
+	for i := 0; i < 10; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	count := 0
+	m.Iterate(func(key string, value int) bool {
+		count++
+		return count < 3
+	})
+
+	if count != 3 {
+		t.Errorf("Iterate visited %d elements, want 3", count)
+	}
+}
+
+func TestSetDuringIteratePanics(t *testing.T) {
+	m := New[string, int]()
+	m.Set("a", 1)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Set during Iterate to panic.")
+		}
+	}()
+
+	m.Iterate(func(key string, value int) bool {
+		m.Set("b", 2)
+		return true
+	})
+}
+
+func TestDeleteDuringIteratePanics(t *testing.T) {
+	m := New[string, int]()
+	m.Set("a", 1)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Delete during Iterate to panic.")
+		}
+	}()
+
+	m.Iterate(func(key string, value int) bool {
+		m.Delete(key)
+		return true
+	})
+}
+
+func TestCursorDeleteCurrent(t *testing.T) {
+	m := New[string, int]()
+	for i := 0; i < 5; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	// Delete every even-valued element while iterating.
+	for c := m.First(); c != nil; {
+		if c.Value()%2 == 0 {
+			c = c.DeleteCurrent()
+		} else {
+			c = c.Next()
+		}
+	}
+
+	if m.Len() != 2 {
+		t.Fatalf("got %d elements remaining, want 2", m.Len())
+	}
+
+	var keys []string
+	for c := m.First(); c != nil; c = c.Next() {
+		keys = append(keys, c.Key())
+	}
+
+	want := []string{"1", "3"}
+	if len(keys) != len(want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+	for i, k := range keys {
+		if k != want[i] {
+			t.Errorf("key at position %d = %s, want %s", i, k, want[i])
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// MarshalJSON encodes m as a JSON object whose member order matches the
+// order in which keys were inserted into m.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	first := true
+	for e := m.First(); e != nil; e = e.Next() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		keyJSON, err := marshalMapKey(e.Key())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+
+		valJSON, err := json.Marshal(e.Value())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(valJSON)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalMapKey renders key as a JSON string, following a subset of the
+// rules encoding/json uses for map keys: keys of type string are used
+// as-is, integer kinds are formatted as decimal strings. Any other key
+// type, including named string types and encoding.TextMarshaler
+// implementations, is rejected with an error.
+func marshalMapKey(key interface{}) ([]byte, error) {
+	if s, ok := key.(string); ok {
+		return json.Marshal(s)
+	}
+
+	switch rv := reflect.ValueOf(key); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Marshal(fmt.Sprint(key))
+	default:
+		return nil, fmt.Errorf("orderedmap: unsupported key type %T for JSON marshaling", key)
+	}
+}
+
+// UnmarshalOption configures UnmarshalJSONWithOptions.
+type UnmarshalOption func(*unmarshalOptions)
+
+type unmarshalOptions struct {
+	nestedOrderedMap bool
+	useNumber        bool
+}
+
+// WithNestedOrderedMap makes nested JSON objects decode into
+// *OrderedMap[string, interface{}] rather than map[string]interface{},
+// so key order is preserved at every level of the document. It only has
+// an effect when V is interface{}.
+func WithNestedOrderedMap() UnmarshalOption {
+	return func(o *unmarshalOptions) { o.nestedOrderedMap = true }
+}
+
+// WithNumber makes JSON numbers decode as json.Number instead of
+// float64, mirroring json.Decoder.UseNumber. It affects every number
+// decoded into an interface{}: values when V is interface{}, and
+// interface{} values held inside a concrete V (such as []interface{}).
+func WithNumber() UnmarshalOption {
+	return func(o *unmarshalOptions) { o.useNumber = true }
+}
+
+// UnmarshalJSON decodes a JSON object into m, calling Set in the order
+// its members appear in data so that later iteration over m replays the
+// original document order.
+func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	return m.unmarshalJSON(data, unmarshalOptions{})
+}
+
+// UnmarshalJSONWithOptions decodes a JSON object into m like
+// UnmarshalJSON, with additional control over how values are decoded.
+func (m *OrderedMap[K, V]) UnmarshalJSONWithOptions(data []byte, opts ...UnmarshalOption) error {
+	var cfg unmarshalOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return m.unmarshalJSON(data, cfg)
+}
+
+func (m *OrderedMap[K, V]) unmarshalJSON(data []byte, cfg unmarshalOptions) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("orderedmap: expected JSON object, got %v", tok)
+	}
+
+	if m.mapper == nil {
+		*m = *New[K, V]()
+	}
+
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		keyStr, ok := keyTok.(string)
+		if !ok {
+			return fmt.Errorf("orderedmap: expected string key, got %v", keyTok)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		key, err := unmarshalMapKey[K](keyStr)
+		if err != nil {
+			return err
+		}
+
+		value, err := decodeValue[V](raw, cfg)
+		if err != nil {
+			return err
+		}
+
+		m.Set(key, value)
+	}
+
+	// consume the closing '}'
+	_, err = dec.Token()
+	return err
+}
+
+// unmarshalMapKey converts a JSON object's string key back into K,
+// mirroring the inverse of marshalMapKey.
+func unmarshalMapKey[K comparable](s string) (K, error) {
+	var zero K
+
+	if _, ok := any(zero).(string); ok {
+		return any(s).(K), nil
+	}
+
+	rv := reflect.New(reflect.TypeOf(zero)).Elem()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return zero, err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return zero, err
+		}
+		rv.SetUint(n)
+	default:
+		return zero, fmt.Errorf("orderedmap: unsupported key type %T for JSON unmarshaling", zero)
+	}
+
+	return rv.Interface().(K), nil
+}
+
+// decodeValue decodes raw into a V. When V's underlying kind is
+// interface{}, nested objects and json.Number handling are driven by
+// cfg via decodeAny; otherwise raw is decoded directly into V.
+func decodeValue[V any](raw json.RawMessage, cfg unmarshalOptions) (V, error) {
+	var v V
+
+	if reflect.TypeOf(&v).Elem().Kind() == reflect.Interface {
+		decoded, err := decodeAny(raw, cfg)
+		if err != nil {
+			return v, err
+		}
+		if decoded == nil {
+			return v, nil
+		}
+		reflect.ValueOf(&v).Elem().Set(reflect.ValueOf(decoded))
+		return v, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if cfg.useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// decodeAny decodes raw into an interface{}, recursing into objects and
+// arrays so that options like WithNestedOrderedMap and WithNumber apply
+// throughout the whole value, not just at the top level.
+func decodeAny(raw json.RawMessage, cfg unmarshalOptions) (interface{}, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("orderedmap: empty JSON value")
+	}
+
+	switch trimmed[0] {
+	case '{':
+		if cfg.nestedOrderedMap {
+			nested := New[string, interface{}]()
+			if err := nested.unmarshalJSON(raw, cfg); err != nil {
+				return nil, err
+			}
+			return nested, nil
+		}
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := decodeAny(item, cfg)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if cfg.useNumber {
+		dec.UseNumber()
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,130 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSONPreservesOrder(t *testing.T) {
+	m := New[string, int]()
+	m.Set("z", 1)
+	m.Set("a", 2)
+	m.Set("m", 3)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	want := `{"z":1,"a":2,"m":3}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestUnmarshalJSONPreservesOrder(t *testing.T) {
+	m := New[string, interface{}]()
+
+	data := []byte(`{"z":1,"a":2,"m":3}`)
+	if err := json.Unmarshal(data, m); err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+
+	wantKeys := []string{"z", "a", "m"}
+	i := 0
+	for e := m.First(); e != nil; e = e.Next() {
+		if e.Key() != wantKeys[i] {
+			t.Errorf("key at position %d = %s, want %s", i, e.Key(), wantKeys[i])
+		}
+		i++
+	}
+	if i != len(wantKeys) {
+		t.Errorf("got %d keys, want %d", i, len(wantKeys))
+	}
+}
+
+func TestUnmarshalJSONNestedOrderedMap(t *testing.T) {
+	m := New[string, interface{}]()
+
+	data := []byte(`{"outer":{"z":1,"a":2}}`)
+	if err := m.UnmarshalJSONWithOptions(data, WithNestedOrderedMap()); err != nil {
+		t.Fatalf("UnmarshalJSONWithOptions failed: %v", err)
+	}
+
+	outer, exist := m.Get("outer")
+	if !exist {
+		t.Fatal("expected \"outer\" key to be present.")
+	}
+
+	nested, ok := outer.(*OrderedMap[string, interface{}])
+	if !ok {
+		t.Fatalf("expected nested value to be *OrderedMap[string, interface{}], got %T", outer)
+	}
+
+	wantKeys := []string{"z", "a"}
+	i := 0
+	for e := nested.First(); e != nil; e = e.Next() {
+		if e.Key() != wantKeys[i] {
+			t.Errorf("nested key at position %d = %s, want %s", i, e.Key(), wantKeys[i])
+		}
+		i++
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	m := New[string, interface{}]()
+
+	data := []byte(`{"a":null,"b":1}`)
+	if err := json.Unmarshal(data, m); err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+
+	val, exist := m.Get("a")
+	if !exist {
+		t.Fatal("expected \"a\" key to be present.")
+	}
+	if val != nil {
+		t.Errorf("got %v, want nil", val)
+	}
+}
+
+func TestUnmarshalJSONNestedNull(t *testing.T) {
+	m := New[string, interface{}]()
+
+	data := []byte(`{"outer":{"a":null}}`)
+	if err := m.UnmarshalJSONWithOptions(data, WithNestedOrderedMap()); err != nil {
+		t.Fatalf("UnmarshalJSONWithOptions failed: %v", err)
+	}
+
+	outer, exist := m.Get("outer")
+	if !exist {
+		t.Fatal("expected \"outer\" key to be present.")
+	}
+
+	nested, ok := outer.(*OrderedMap[string, interface{}])
+	if !ok {
+		t.Fatalf("expected nested value to be *OrderedMap[string, interface{}], got %T", outer)
+	}
+
+	val, exist := nested.Get("a")
+	if !exist {
+		t.Fatal("expected nested \"a\" key to be present.")
+	}
+	if val != nil {
+		t.Errorf("got %v, want nil", val)
+	}
+}
+
+func TestUnmarshalJSONWithNumber(t *testing.T) {
+	m := New[string, interface{}]()
+
+	data := []byte(`{"n":123456789012345}`)
+	if err := m.UnmarshalJSONWithOptions(data, WithNumber()); err != nil {
+		t.Fatalf("UnmarshalJSONWithOptions failed: %v", err)
+	}
+
+	val, _ := m.Get("n")
+	if _, ok := val.(json.Number); !ok {
+		t.Errorf("expected json.Number, got %T", val)
+	}
+}
@@ -0,0 +1,17 @@
+package orderedmap
+
+// StringMap is the pre-generics ordered map, keyed by string and valued
+// by interface{}. It is kept so code written against the old API keeps
+// compiling; new code should use OrderedMap[K, V] directly.
+//
+// Deprecated: use OrderedMap[string, any] (or any other K, V) instead.
+type StringMap = OrderedMap[string, interface{}]
+
+// NewString returns an instance of the original string/interface{}
+// ordered map.
+//
+// Deprecated: use New[string, any]() (or New with other type parameters)
+// instead.
+func NewString() *StringMap {
+	return New[string, interface{}]()
+}
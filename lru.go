@@ -0,0 +1,33 @@
+package orderedmap
+
+// Option configures a map created with NewWithCapacity.
+type Option[K comparable, V any] func(*OrderedMap[K, V])
+
+// AccessOrder makes Get and Set move the touched element to the back of
+// the iteration order, turning the map into an LRU cache when combined
+// with NewWithCapacity: the front of the list is always the least
+// recently used element, which is what gets evicted first.
+func AccessOrder[K comparable, V any](enabled bool) Option[K, V] {
+	return func(m *OrderedMap[K, V]) { m.accessOrder = enabled }
+}
+
+// OnEvict registers a callback invoked with the key and value of every
+// element NewWithCapacity's capacity limit evicts.
+func OnEvict[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(m *OrderedMap[K, V]) { m.onEvict = fn }
+}
+
+// NewWithCapacity returns an ordered map like New, but that never holds
+// more than capacity elements: once Set would exceed it, the element at
+// the front of the iteration order is evicted first. A capacity of zero
+// or less sets no limit. Combine with AccessOrder to get LRU-cache
+// eviction semantics.
+func NewWithCapacity[K comparable, V any](capacity int, opts ...Option[K, V]) *OrderedMap[K, V] {
+	m := New[K, V]()
+	m.capacity = capacity
+
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
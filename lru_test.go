@@ -0,0 +1,131 @@
+package orderedmap
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNewWithCapacityEvictsFront(t *testing.T) {
+	var evicted []string
+	m := NewWithCapacity[string, int](3, OnEvict[string, int](func(key string, value int) {
+		evicted = append(evicted, key)
+	}))
+
+	for i := 0; i < 5; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+
+	if m.Len() != 3 {
+		t.Fatalf("got %d elements, want 3", m.Len())
+	}
+
+	wantEvicted := []string{"0", "1"}
+	if len(evicted) != len(wantEvicted) {
+		t.Fatalf("evicted %v, want %v", evicted, wantEvicted)
+	}
+	for i, key := range wantEvicted {
+		if evicted[i] != key {
+			t.Errorf("evicted[%d] = %s, want %s", i, evicted[i], key)
+		}
+	}
+
+	var keys []string
+	for c := m.First(); c != nil; c = c.Next() {
+		keys = append(keys, c.Key())
+	}
+	wantKeys := []string{"2", "3", "4"}
+	for i, key := range wantKeys {
+		if keys[i] != key {
+			t.Errorf("keys[%d] = %s, want %s", i, keys[i], key)
+		}
+	}
+}
+
+func TestAccessOrderLRU(t *testing.T) {
+	m := NewWithCapacity[string, int](3, AccessOrder[string, int](true))
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	// Touch "a" so it is no longer the least recently used.
+	m.Get("a")
+
+	// Inserting a 4th element should evict "b", the new least recently used.
+	m.Set("d", 4)
+
+	if _, exist := m.Get("b"); exist {
+		t.Error("expected \"b\" to have been evicted as least recently used.")
+	}
+	if _, exist := m.Get("a"); !exist {
+		t.Error("expected \"a\" to still be present.")
+	}
+}
+
+func TestGetDuringIterateDoesNotReorder(t *testing.T) {
+	m := NewWithCapacity[string, int](3, AccessOrder[string, int](true))
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	var keys []string
+	m.Iterate(func(key string, value int) bool {
+		m.Get(key)
+		keys = append(keys, key)
+		return true
+	})
+
+	want := []string{"a", "b", "c"}
+	if len(keys) != len(want) {
+		t.Fatalf("Iterate visited %v, want %v", keys, want)
+	}
+	for i, key := range want {
+		if keys[i] != key {
+			t.Errorf("keys[%d] = %s, want %s", i, keys[i], key)
+		}
+	}
+}
+
+func TestMoveToFrontAndBack(t *testing.T) {
+	m := New[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	m.MoveToFront("c")
+	m.MoveToBack("a")
+
+	var keys []string
+	for c := m.First(); c != nil; c = c.Next() {
+		keys = append(keys, c.Key())
+	}
+
+	want := []string{"c", "b", "a"}
+	for i, key := range want {
+		if keys[i] != key {
+			t.Errorf("keys[%d] = %s, want %s", i, keys[i], key)
+		}
+	}
+}
+
+func TestMoveBeforeAndAfter(t *testing.T) {
+	m := New[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	m.MoveBefore("c", "a")
+	m.MoveAfter("b", "a")
+
+	var keys []string
+	for cur := m.First(); cur != nil; cur = cur.Next() {
+		keys = append(keys, cur.Key())
+	}
+
+	want := []string{"c", "a", "b"}
+	for i, key := range want {
+		if keys[i] != key {
+			t.Errorf("keys[%d] = %s, want %s", i, keys[i], key)
+		}
+	}
+}
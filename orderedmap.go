@@ -4,79 +4,117 @@
 //
 // All operations have O(1) time complexity.
 //
-// To iterate over an ordered map (where m is a *OrderedMap):
-//	for e:= m.Front; e != nil; e = e.Next() {
-//		key := e.Key
-//		value:= e.Value
-//		// do something with e.Value
+// To iterate over an ordered map (where m is a *OrderedMap[K, V]):
+//
+//	for c := m.First(); c != nil; c = c.Next() {
+//		key := c.Key()
+//		value := c.Value()
+//		// do something with value
+//	}
+//
+// If you want to delete an element while iterating, use DeleteCurrent
+// instead of calling Delete:
+//
+//	for c := m.First(); c != nil; {
+//		if shouldDelete(c.Key()) {
+//			c = c.DeleteCurrent()
+//		} else {
+//			c = c.Next()
+//		}
 //	}
 //
-// If you want to delete element while iterating,
-// MUST use the following pattern:
-//  var next *orderedmap.Element
-// 	for e := m.First(); e != nil; e = next {
-//		key := e.Key
-// 		// assign e.Next() to the next before deleting e
-//  	next = e.Next()
-// 		m.Delete(key)
-// 	}
+// For the common case of visiting every element, Iterate is a
+// zero-allocation shorthand for the loop above.
 package orderedmap
 
 import (
 	"container/list"
 )
 
-// const chbufSize = 32
-
 // OrderedMap holds key-value pairs and remembers
 // the original insertion order of the keys.
 //
 // `key` stores in the map, map's value is the element of the list.
 // for the convenience of iteration, the `Value` of list element stores
 // the aggregate data of `key` and `value`.
-type OrderedMap struct {
-	mapper map[string]*list.Element
-	lister *list.List
+type OrderedMap[K comparable, V any] struct {
+	mapper      map[K]*list.Element
+	lister      *list.List
+	iterating   int
+	capacity    int
+	accessOrder bool
+	onEvict     func(key K, value V)
 }
 
-// New return an instance of ordered map.
-func New() *OrderedMap {
-	return &OrderedMap{
-		mapper: make(map[string]*list.Element),
+// New return an instance of ordered map keyed by K and valued by V.
+func New[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		mapper: make(map[K]*list.Element),
 		lister: list.New(),
 	}
 }
 
 // ElemVal encapsulates the key-value pair which
 // stores in the `Value` field of list element.
-type ElemVal struct {
-	Key   string
-	Value interface{}
+type ElemVal[K comparable, V any] struct {
+	Key   K
+	Value V
 }
 
-// Set sets the given value under the specified key.
-func (m *OrderedMap) Set(key string, value interface{}) {
+// Set sets the given value under the specified key. If m was created
+// with NewWithCapacity and key is new, Set may evict the oldest element
+// (see NewWithCapacity); if m has AccessOrder enabled, setting an
+// existing key moves it to the back.
+//
+// Set panics if called while m is being ranged over with Iterate, since
+// that would invalidate the range in progress; delete the element the
+// cursor currently points at with Cursor.DeleteCurrent instead.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+
 	elem, exist := m.mapper[key]
 	if !exist {
-		elem = m.lister.PushBack(&ElemVal{key, value})
+		elem = m.lister.PushBack(&ElemVal[K, V]{key, value})
 		m.mapper[key] = elem
-	} else {
-		elem.Value.(*ElemVal).Value = value
+		m.evictIfOverCapacity()
+		return
+	}
+
+	elem.Value.(*ElemVal[K, V]).Value = value
+	if m.accessOrder {
+		m.lister.MoveToBack(elem)
 	}
 }
 
-// Get retrieves an values from ordered map under given key.
-// If no value was associated with the given key, will return false.
-func (m *OrderedMap) Get(key string) (interface{}, bool) {
+// Get retrieves a value from ordered map under given key.
+// If no value was associated with the given key, will return false. If
+// m has AccessOrder enabled, a successful Get moves key to the back,
+// unless m is currently being ranged over with Iterate, in which case
+// the move is skipped to avoid invalidating the range in progress.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
 	elem, exist := m.mapper[key]
 	if !exist {
-		return nil, false
+		var zero V
+		return zero, false
 	}
-	return elem.Value.(*ElemVal).Value, true
+	if m.accessOrder && m.iterating == 0 {
+		m.lister.MoveToBack(elem)
+	}
+	return elem.Value.(*ElemVal[K, V]).Value, true
 }
 
 // Delete deletes an item from the ordered map.
-func (m *OrderedMap) Delete(key string) {
+//
+// Delete panics if called while m is being ranged over with Iterate; use
+// Cursor.DeleteCurrent to delete the element the cursor currently points
+// at instead.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+
 	elem, exist := m.mapper[key]
 	if !exist {
 		return
@@ -88,64 +126,147 @@ func (m *OrderedMap) Delete(key string) {
 
 // Len returns the number of elements of ordered map m.
 // The complexity is O(1).
-func (m *OrderedMap) Len() int { return m.lister.Len() }
+func (m *OrderedMap[K, V]) Len() int { return m.lister.Len() }
 
-// Iter returns a buffered iterator which could be used in a for range loop.
-//
-// Deprecated: using channel as iterator, you can't break in a `for` loop,
-// otherwise the following goroutine will block forever and cause goroutine leak.
-// func (m *OrderedMap) Iter() <-chan ElemVal {
-// 	ch := make(chan ElemVal, chbufSize)
-// 	go func() {
-// 		var next *list.Element
-// 		for e := m.lister.Front(); e != nil; e = next {
-// 			// assign e.Next() to `next` before sending `e.Value` to channel,
-// 			// make the delete operation while iterating safe.
-// 			next = e.Next()
-// 			ch <- *e.Value.(*ElemVal)
-// 		}
-// 		close(ch)
-// 	}()
-// 	return ch
-// }
-
-// Element encapsulates the underlying list element and the map's
-// key-value pair, to provide a `Next` method for iterating.
-type Element struct {
+// MoveToFront moves the element under key to the front of m's iteration
+// order. It is a no-op if key is not present.
+func (m *OrderedMap[K, V]) MoveToFront(key K) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+	if elem, exist := m.mapper[key]; exist {
+		m.lister.MoveToFront(elem)
+	}
+}
+
+// MoveToBack moves the element under key to the back of m's iteration
+// order. It is a no-op if key is not present.
+func (m *OrderedMap[K, V]) MoveToBack(key K) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+	if elem, exist := m.mapper[key]; exist {
+		m.lister.MoveToBack(elem)
+	}
+}
+
+// MoveBefore moves the element under key to sit immediately before the
+// element under pivot. It is a no-op unless both keys are present.
+func (m *OrderedMap[K, V]) MoveBefore(key, pivot K) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+	elem, exist := m.mapper[key]
+	pivotElem, pivotExist := m.mapper[pivot]
+	if !exist || !pivotExist {
+		return
+	}
+	m.lister.MoveBefore(elem, pivotElem)
+}
+
+// MoveAfter moves the element under key to sit immediately after the
+// element under pivot. It is a no-op unless both keys are present.
+func (m *OrderedMap[K, V]) MoveAfter(key, pivot K) {
+	if m.iterating > 0 {
+		panic("orderedmap: concurrent modification during iteration")
+	}
+	elem, exist := m.mapper[key]
+	pivotElem, pivotExist := m.mapper[pivot]
+	if !exist || !pivotExist {
+		return
+	}
+	m.lister.MoveAfter(elem, pivotElem)
+}
+
+// evictIfOverCapacity removes elements from the front of m until m is at
+// or under its capacity, invoking onEvict (if set) for each one. It is a
+// no-op for maps created with New, which have no capacity limit.
+func (m *OrderedMap[K, V]) evictIfOverCapacity() {
+	if m.capacity <= 0 {
+		return
+	}
+
+	for m.lister.Len() > m.capacity {
+		front := m.lister.Front()
+		ev := front.Value.(*ElemVal[K, V])
+
+		m.lister.Remove(front)
+		delete(m.mapper, ev.Key)
+
+		if m.onEvict != nil {
+			m.onEvict(ev.Key, ev.Value)
+		}
+	}
+}
+
+// Cursor is a position within an OrderedMap, obtained from First and
+// advanced with Next. A Cursor is mutated in place as it advances, so
+// ranging over a map with First/Next performs no per-element allocation.
+type Cursor[K comparable, V any] struct {
+	m    *OrderedMap[K, V]
 	elem *list.Element
-	*ElemVal
 }
 
-// First returns the first element of ordered map or nil if the orded map is empty.
-func (m *OrderedMap) First() *Element {
-	front := m.lister.Front()
+func (c *Cursor[K, V]) ev() *ElemVal[K, V] { return c.elem.Value.(*ElemVal[K, V]) }
+
+// Key returns the key the cursor currently points at.
+func (c *Cursor[K, V]) Key() K { return c.ev().Key }
 
+// Value returns the value the cursor currently points at.
+func (c *Cursor[K, V]) Value() V { return c.ev().Value }
+
+// First returns a cursor positioned at the first element of m, or nil if
+// m is empty.
+func (m *OrderedMap[K, V]) First() *Cursor[K, V] {
+	front := m.lister.Front()
 	if front == nil {
 		return nil
 	}
+	return &Cursor[K, V]{m: m, elem: front}
+}
 
-	return &Element{
-		elem: front,
-		ElemVal: &ElemVal{
-			Key:   front.Value.(*ElemVal).Key,
-			Value: front.Value.(*ElemVal).Value,
-		},
+// Next advances the cursor to the next element and returns it, or nil
+// once there are no elements left. Next mutates and returns the same
+// Cursor instance, so it does not allocate.
+func (c *Cursor[K, V]) Next() *Cursor[K, V] {
+	next := c.elem.Next()
+	if next == nil {
+		return nil
 	}
+	c.elem = next
+	return c
 }
 
-// Next returns the next ordered map element or nil.
-func (e *Element) Next() *Element {
-	next := e.elem.Next()
+// DeleteCurrent removes the element the cursor currently points at and
+// advances to the one after it, returning it (or nil if none remains).
+// Unlike Delete, DeleteCurrent is always safe to call while iterating.
+func (c *Cursor[K, V]) DeleteCurrent() *Cursor[K, V] {
+	next := c.elem.Next()
+
+	delete(c.m.mapper, c.ev().Key)
+	c.m.lister.Remove(c.elem)
 
 	if next == nil {
 		return nil
 	}
+	c.elem = next
+	return c
+}
+
+// Iterate calls fn once for each element of m in insertion order,
+// stopping early if fn returns false. Like ranging with First/Next, it
+// performs no per-element allocation.
+//
+// While fn is running, calling Set or Delete on m panics; to remove
+// elements while iterating use a First/Next loop with
+// Cursor.DeleteCurrent instead of Iterate.
+func (m *OrderedMap[K, V]) Iterate(fn func(key K, value V) bool) {
+	m.iterating++
+	defer func() { m.iterating-- }()
 
-	return &Element{
-		elem: next,
-		ElemVal: &ElemVal{
-			Key:   next.Value.(*ElemVal).Key,
-			Value: next.Value.(*ElemVal).Value,
-		},
+	for c := m.First(); c != nil; c = c.Next() {
+		if !fn(c.Key(), c.Value()) {
+			return
+		}
 	}
 }
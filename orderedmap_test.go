@@ -7,7 +7,7 @@ import (
 )
 
 func TestMapCreation(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 	if m == nil {
 		t.Error("map is null.")
 	}
@@ -18,7 +18,7 @@ func TestMapCreation(t *testing.T) {
 }
 
 func TestSet(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 
 	m.Set("a", 1)
 	m.Set("b", 2)
@@ -29,7 +29,7 @@ func TestSet(t *testing.T) {
 }
 
 func TestGet(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 
 	// Get a missing element.
 	val, exist := m.Get("a")
@@ -38,8 +38,8 @@ func TestGet(t *testing.T) {
 		t.Error("exist should be false when item is missing from map.")
 	}
 
-	if val != nil {
-		t.Error("Missing values should return as null.")
+	if val != 0 {
+		t.Error("Missing values should return as the zero value.")
 	}
 
 	m.Set("a", 1)
@@ -51,23 +51,18 @@ func TestGet(t *testing.T) {
 
 	// Retrieve inserted element.
 	elem, exist := m.Get("a")
-	elemval := elem.(int) // Type assertion.
 
 	if !exist {
 		t.Error("exist should be true for item stored within the map.")
 	}
 
-	if &elemval == nil {
-		t.Error("expecting a number, not null.")
-	}
-
-	if elemval != 2 {
+	if elem != 2 {
 		t.Error("item was modified.")
 	}
 }
 
 func TestDelete(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 
 	m.Set("a", 1)
 
@@ -83,8 +78,8 @@ func TestDelete(t *testing.T) {
 		t.Error("Expecting exist to be false for missing items.")
 	}
 
-	if temp != nil {
-		t.Error("Expecting item to be nil after its removal.")
+	if temp != 0 {
+		t.Error("Expecting item to be the zero value after its removal.")
 	}
 
 	// Remove a none existing element.
@@ -92,7 +87,7 @@ func TestDelete(t *testing.T) {
 }
 
 func TestOrderedMap(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 	if m == nil {
 		t.Error("map is null.")
 	}
@@ -106,7 +101,7 @@ func TestOrderedMap(t *testing.T) {
 }
 
 func TestLen(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 	for i := 0; i < 100; i++ {
 		m.Set(strconv.Itoa(i), i)
 	}
@@ -117,7 +112,7 @@ func TestLen(t *testing.T) {
 }
 
 func TestIterator(t *testing.T) {
-	m := New()
+	m := New[string, int]()
 
 	// Insert 100 elements.
 	for i := 0; i < 100; i++ {
@@ -126,21 +121,18 @@ func TestIterator(t *testing.T) {
 
 	counter := 0
 	// Iterate over elements.
-	for item := range m.Iter() {
-		key := item.Key
-		val := item.Value
+	for e := m.First(); e != nil; e = e.Next() {
+		key := e.Key()
+		val := e.Value()
 
 		if key != strconv.Itoa(counter) {
 			t.Error("key was modified.")
 		}
 
-		if val.(int) != counter {
+		if val != counter {
 			t.Error("val was modified.")
 		}
 
-		if val == nil {
-			t.Error("Expecting an object.")
-		}
 		counter++
 	}
 
@@ -148,3 +140,18 @@ func TestIterator(t *testing.T) {
 		t.Error("We should have counted 100 elements.")
 	}
 }
+
+func TestStringMapCompat(t *testing.T) {
+	m := NewString()
+
+	m.Set("a", 1)
+
+	val, exist := m.Get("a")
+	if !exist {
+		t.Error("exist should be true for item stored within the map.")
+	}
+
+	if val.(int) != 1 {
+		t.Error("item was modified.")
+	}
+}
@@ -0,0 +1,640 @@
+package orderedmap
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// pcolor is a red-black node color. pcolorBB and pcolorNB (double-black
+// and negative-black) only ever appear transiently while Delete is
+// rebalancing a subtree; a tree returned to callers never contains them.
+type pcolor int
+
+const (
+	pcolorR pcolor = iota
+	pcolorB
+	pcolorBB
+	pcolorNB
+)
+
+// pnode is one node of a persistent red-black tree. A nil *pnode
+// represents an empty tree; ptree additionally distinguishes an empty
+// tree that is "double black" (bb == true) from an ordinary empty one,
+// which delete's rebalancing needs to track across a path-copied edit.
+type pnode[Kx any, Vx any] struct {
+	color pcolor
+	left  ptree[Kx, Vx]
+	key   Kx
+	value Vx
+	right ptree[Kx, Vx]
+}
+
+type ptree[Kx any, Vx any] struct {
+	node *pnode[Kx, Vx]
+	bb   bool
+}
+
+func pT[Kx any, Vx any](c pcolor, l ptree[Kx, Vx], k Kx, v Vx, r ptree[Kx, Vx]) ptree[Kx, Vx] {
+	return ptree[Kx, Vx]{node: &pnode[Kx, Vx]{color: c, left: l, key: k, value: v, right: r}}
+}
+
+func pIsBB[Kx any, Vx any](t ptree[Kx, Vx]) bool {
+	if t.node == nil {
+		return t.bb
+	}
+	return t.node.color == pcolorBB
+}
+
+func pBlackerColor(c pcolor) pcolor {
+	switch c {
+	case pcolorNB:
+		return pcolorR
+	case pcolorR:
+		return pcolorB
+	case pcolorB:
+		return pcolorBB
+	default:
+		panic("orderedmap: cannot blacken a double-black color")
+	}
+}
+
+func pRedderColor(c pcolor) pcolor {
+	switch c {
+	case pcolorBB:
+		return pcolorB
+	case pcolorB:
+		return pcolorR
+	case pcolorR:
+		return pcolorNB
+	default:
+		panic("orderedmap: cannot redden a negative-black color")
+	}
+}
+
+// pBlackerTree and pRedderTree shift an entire subtree's root by one
+// step along the R < B < BB (and NB < R) color scale; this is what lets
+// bubble push a double-black violation up toward the root.
+func pBlackerTree[Kx any, Vx any](t ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if t.node == nil {
+		return ptree[Kx, Vx]{bb: true}
+	}
+	n := *t.node
+	n.color = pBlackerColor(n.color)
+	return ptree[Kx, Vx]{node: &n}
+}
+
+func pRedderTree[Kx any, Vx any](t ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if t.node == nil {
+		return ptree[Kx, Vx]{}
+	}
+	n := *t.node
+	n.color = pRedderColor(n.color)
+	return ptree[Kx, Vx]{node: &n}
+}
+
+// predden forces a node fully red, used by balance's double-black
+// rotations (as opposed to pRedderTree, which only steps one shade).
+func predden[Kx any, Vx any](t ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if t.node == nil {
+		return t
+	}
+	n := *t.node
+	n.color = pcolorR
+	return ptree[Kx, Vx]{node: &n}
+}
+
+// pblacken forces t's root black, collapsing a leftover double-black
+// empty tree down to a plain empty one. insert and remove both finish by
+// calling this, so callers never observe pcolorBB/pcolorNB/the bb flag.
+func pblacken[Kx any, Vx any](t ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if t.node == nil {
+		return ptree[Kx, Vx]{}
+	}
+	if t.node.color == pcolorR || t.node.color == pcolorBB {
+		n := *t.node
+		n.color = pcolorB
+		return ptree[Kx, Vx]{node: &n}
+	}
+	return t
+}
+
+func pRed[Kx any, Vx any](t ptree[Kx, Vx]) (*pnode[Kx, Vx], bool) {
+	if t.node != nil && t.node.color == pcolorR {
+		return t.node, true
+	}
+	return nil, false
+}
+
+// pbalance implements Okasaki's four red-red-violation rotations (for
+// c == pcolorB) plus the Germane/Might rotations that absorb a
+// double-black child produced by deletion (for c == pcolorBB).
+func pbalance[Kx any, Vx any](c pcolor, l ptree[Kx, Vx], k Kx, v Vx, r ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if c == pcolorB || c == pcolorBB {
+		// Insert's red-red fixups produce a red root; delete's equivalent
+		// double-black fixups only need to shed one level of blackness.
+		rootColor := pcolorR
+		if c == pcolorBB {
+			rootColor = pcolorB
+		}
+
+		if ln, ok := pRed(l); ok {
+			if lln, ok2 := pRed(ln.left); ok2 {
+				return pT(rootColor,
+					pT(pcolorB, lln.left, lln.key, lln.value, lln.right),
+					ln.key, ln.value,
+					pT(pcolorB, ln.right, k, v, r))
+			}
+			if lrn, ok2 := pRed(ln.right); ok2 {
+				return pT(rootColor,
+					pT(pcolorB, ln.left, ln.key, ln.value, lrn.left),
+					lrn.key, lrn.value,
+					pT(pcolorB, lrn.right, k, v, r))
+			}
+		}
+		if rn, ok := pRed(r); ok {
+			if rln, ok2 := pRed(rn.left); ok2 {
+				return pT(rootColor,
+					pT(pcolorB, l, k, v, rln.left),
+					rln.key, rln.value,
+					pT(pcolorB, rln.right, rn.key, rn.value, rn.right))
+			}
+			if rrn, ok2 := pRed(rn.right); ok2 {
+				return pT(rootColor,
+					pT(pcolorB, l, k, v, rn.left),
+					rn.key, rn.value,
+					pT(pcolorB, rrn.left, rrn.key, rrn.value, rrn.right))
+			}
+		}
+	}
+
+	if c == pcolorBB {
+		if rn := r.node; rn != nil && rn.color == pcolorNB {
+			if rln := rn.left.node; rln != nil && rln.color == pcolorB {
+				if rn.right.node != nil && rn.right.node.color == pcolorB {
+					d := rn.right
+					return pT(pcolorB,
+						pT(pcolorB, l, k, v, rln.left),
+						rln.key, rln.value,
+						pbalance(pcolorB, rln.right, rn.key, rn.value, predden(d)))
+				}
+			}
+		}
+		if ln := l.node; ln != nil && ln.color == pcolorNB {
+			if lrn := ln.right.node; lrn != nil && lrn.color == pcolorB {
+				if ln.left.node != nil && ln.left.node.color == pcolorB {
+					a := ln.left
+					return pT(pcolorB,
+						pbalance(pcolorB, predden(a), ln.key, ln.value, lrn.left),
+						lrn.key, lrn.value,
+						pT(pcolorB, lrn.right, k, v, r))
+				}
+			}
+		}
+	}
+
+	return pT(c, l, k, v, r)
+}
+
+// pbubble re-establishes the balance invariant after one of l, r comes
+// back double-black from a recursive delete, by stepping every color
+// involved one shade blacker/redder before handing off to pbalance.
+func pbubble[Kx any, Vx any](c pcolor, l ptree[Kx, Vx], k Kx, v Vx, r ptree[Kx, Vx]) ptree[Kx, Vx] {
+	if pIsBB(l) || pIsBB(r) {
+		return pbalance(pBlackerColor(c), pRedderTree(l), k, v, pRedderTree(r))
+	}
+	return pbalance(c, l, k, v, r)
+}
+
+// ptreeOps bundles a less function with the persistent red-black tree
+// operations, so the same implementation serves both the insertion-order
+// index (keyed by int64 sequence number) and the hash index (keyed by
+// uint64) that PersistentOrderedMap keeps.
+type ptreeOps[Kx any, Vx any] struct {
+	less func(a, b Kx) bool
+}
+
+func (o ptreeOps[Kx, Vx]) get(t ptree[Kx, Vx], k Kx) (Vx, bool) {
+	for t.node != nil {
+		n := t.node
+		switch {
+		case o.less(k, n.key):
+			t = n.left
+		case o.less(n.key, k):
+			t = n.right
+		default:
+			return n.value, true
+		}
+	}
+	var zero Vx
+	return zero, false
+}
+
+func (o ptreeOps[Kx, Vx]) insert(t ptree[Kx, Vx], k Kx, v Vx) ptree[Kx, Vx] {
+	return pblacken(o.ins(t, k, v))
+}
+
+func (o ptreeOps[Kx, Vx]) ins(t ptree[Kx, Vx], k Kx, v Vx) ptree[Kx, Vx] {
+	if t.node == nil {
+		return pT(pcolorR, ptree[Kx, Vx]{}, k, v, ptree[Kx, Vx]{})
+	}
+	n := t.node
+	switch {
+	case o.less(k, n.key):
+		return pbalance(n.color, o.ins(n.left, k, v), n.key, n.value, n.right)
+	case o.less(n.key, k):
+		return pbalance(n.color, n.left, n.key, n.value, o.ins(n.right, k, v))
+	default:
+		return pT(n.color, n.left, k, v, n.right)
+	}
+}
+
+func (o ptreeOps[Kx, Vx]) remove(t ptree[Kx, Vx], k Kx) ptree[Kx, Vx] {
+	return pblacken(o.del(t, k))
+}
+
+func (o ptreeOps[Kx, Vx]) del(t ptree[Kx, Vx], k Kx) ptree[Kx, Vx] {
+	if t.node == nil {
+		return t
+	}
+	n := t.node
+	switch {
+	case o.less(k, n.key):
+		return pbubble(n.color, o.del(n.left, k), n.key, n.value, n.right)
+	case o.less(n.key, k):
+		return pbubble(n.color, n.left, n.key, n.value, o.del(n.right, k))
+	default:
+		return o.removeNode(t)
+	}
+}
+
+func (o ptreeOps[Kx, Vx]) removeNode(t ptree[Kx, Vx]) ptree[Kx, Vx] {
+	n := t.node
+
+	if n.left.node == nil && n.right.node == nil {
+		if n.color == pcolorB {
+			return ptree[Kx, Vx]{bb: true}
+		}
+		return ptree[Kx, Vx]{}
+	}
+	if n.color == pcolorB && n.left.node == nil && n.right.node != nil && n.right.node.color == pcolorR {
+		rn := n.right.node
+		return pT(pcolorB, rn.left, rn.key, rn.value, rn.right)
+	}
+	if n.color == pcolorB && n.right.node == nil && n.left.node != nil && n.left.node.color == pcolorR {
+		ln := n.left.node
+		return pT(pcolorB, ln.left, ln.key, ln.value, ln.right)
+	}
+
+	minKey, minVal, newRight := o.delMin(n.right)
+	return pbubble(n.color, n.left, minKey, minVal, newRight)
+}
+
+// delMin removes and returns the minimum-keyed entry of t, mirroring
+// removeNode's base cases for the node it ends up splicing out.
+func (o ptreeOps[Kx, Vx]) delMin(t ptree[Kx, Vx]) (Kx, Vx, ptree[Kx, Vx]) {
+	n := t.node
+	if n.left.node == nil {
+		switch {
+		case n.color == pcolorR:
+			return n.key, n.value, n.right
+		case n.right.node == nil:
+			return n.key, n.value, ptree[Kx, Vx]{bb: true}
+		case n.right.node.color == pcolorR:
+			rn := n.right.node
+			return n.key, n.value, pT(pcolorB, rn.left, rn.key, rn.value, rn.right)
+		}
+	}
+	minKey, minVal, newLeft := o.delMin(n.left)
+	return minKey, minVal, pbubble(n.color, newLeft, n.key, n.value, n.right)
+}
+
+// pbuildSorted builds a persistent red-black tree holding exactly n
+// entries, yielded by next in ascending key order, in O(n) time and
+// allocations. It is used to construct a tree directly from an
+// already-ordered sequence (e.g. Transient.Freeze) instead of paying the
+// O(log n) path-copying and rebalancing cost of n individual inserts.
+func pbuildSorted[Kx any, Vx any](n int, next func() (Kx, Vx)) ptree[Kx, Vx] {
+	levels := 0
+	for (1<<(levels+1))-1 <= n {
+		levels++
+	}
+	extra := n - (1<<levels - 1)
+	return pbuildPerfect(levels, &extra, next)
+}
+
+// pbuildPerfect builds a perfect all-black binary tree of 2^levels - 1
+// nodes, then attaches a red fringe of up to two extra nodes under each
+// bottom-level black leaf (left child first) to absorb the remaining
+// *extra entries. Every root-to-nil path passes through exactly levels
+// black nodes plus the nil itself, whether or not it ends in a red
+// fringe node, so the result already satisfies the red-black invariants
+// without any rotation.
+func pbuildPerfect[Kx any, Vx any](levels int, extra *int, next func() (Kx, Vx)) ptree[Kx, Vx] {
+	if levels == 0 {
+		if *extra > 0 {
+			*extra--
+			k, v := next()
+			return pT(pcolorR, ptree[Kx, Vx]{}, k, v, ptree[Kx, Vx]{})
+		}
+		return ptree[Kx, Vx]{}
+	}
+
+	left := pbuildPerfect[Kx, Vx](levels-1, extra, next)
+	k, v := next()
+	right := pbuildPerfect[Kx, Vx](levels-1, extra, next)
+	return pT(pcolorB, left, k, v, right)
+}
+
+// pentry is the payload stored by PersistentOrderedMap's
+// insertion-order index, keyed by sequence number.
+type pentry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// pHashEntry is one element of a hash-bucket in the key index; Seq
+// lets Set/Delete locate the matching entry in the insertion-order
+// index without a second hash lookup.
+type pHashEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Seq   int64
+}
+
+func pSeqOps[K comparable, V any]() ptreeOps[int64, pentry[K, V]] {
+	return ptreeOps[int64, pentry[K, V]]{less: func(a, b int64) bool { return a < b }}
+}
+
+func pHashOps[K comparable, V any]() ptreeOps[uint64, []pHashEntry[K, V]] {
+	return ptreeOps[uint64, []pHashEntry[K, V]]{less: func(a, b uint64) bool { return a < b }}
+}
+
+func phashKey(key interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	return h.Sum64()
+}
+
+// PersistentOrderedMap is an immutable, insertion-order-preserving map:
+// Set and Delete return a new map that shares almost all of its
+// structure with the receiver, so older snapshots stay valid and cheap
+// to keep around (e.g. across goroutines, or for undo history).
+//
+// Internally it keeps two persistent red-black trees: one keyed by an
+// insertion sequence number (so iteration replays insertion order), and
+// one keyed by each key's hash (so Get/Set/Delete are O(log n) without
+// requiring K to be ordered). Every mutation path-copies only the
+// O(log n) nodes on the edited root-to-leaf path of each tree; unchanged
+// subtrees are shared by pointer with the original.
+//
+// The key index is a red-black tree rather than a hash-array-mapped
+// trie: this is a deliberate substitution, not an oversight. Both give
+// O(log n) Get/Set/Delete with structural sharing on every edit; a HAMT
+// trades that logarithm for a larger branching factor (O(log32 n) in
+// practice) at the cost of a second tree shape (and its own rebalancing
+// rules) to maintain alongside bySeq, for an implementation this size
+// that tradeoff wasn't worth it.
+type PersistentOrderedMap[K comparable, V any] struct {
+	bySeq   ptree[int64, pentry[K, V]]
+	byHash  ptree[uint64, []pHashEntry[K, V]]
+	nextSeq int64
+	count   int
+}
+
+// NewPersistent returns an empty PersistentOrderedMap keyed by K and
+// valued by V.
+func NewPersistent[K comparable, V any]() *PersistentOrderedMap[K, V] {
+	return &PersistentOrderedMap[K, V]{}
+}
+
+func (m *PersistentOrderedMap[K, V]) findSeq(key K) (pHashEntry[K, V], bool) {
+	bucket, ok := pHashOps[K, V]().get(m.byHash, phashKey(key))
+	if !ok {
+		var zero pHashEntry[K, V]
+		return zero, false
+	}
+	for _, entry := range bucket {
+		if entry.Key == key {
+			return entry, true
+		}
+	}
+	var zero pHashEntry[K, V]
+	return zero, false
+}
+
+// Get retrieves the value stored under key. If no value is associated
+// with key, found is false.
+func (m *PersistentOrderedMap[K, V]) Get(key K) (value V, found bool) {
+	entry, ok := m.findSeq(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return entry.Value, true
+}
+
+// Len returns the number of elements in m.
+func (m *PersistentOrderedMap[K, V]) Len() int { return m.count }
+
+// Set returns a new map with value stored under key, leaving m
+// unmodified. Setting an existing key updates its value in place
+// without moving its position in iteration order, matching OrderedMap.
+func (m *PersistentOrderedMap[K, V]) Set(key K, value V) *PersistentOrderedMap[K, V] {
+	h := phashKey(key)
+	bucket, _ := pHashOps[K, V]().get(m.byHash, h)
+
+	if existing, ok := m.findSeq(key); ok {
+		newBucket := make([]pHashEntry[K, V], len(bucket))
+		copy(newBucket, bucket)
+		for i, entry := range newBucket {
+			if entry.Key == key {
+				newBucket[i].Value = value
+				break
+			}
+		}
+
+		return &PersistentOrderedMap[K, V]{
+			bySeq:   pSeqOps[K, V]().insert(m.bySeq, existing.Seq, pentry[K, V]{Key: key, Value: value}),
+			byHash:  pHashOps[K, V]().insert(m.byHash, h, newBucket),
+			nextSeq: m.nextSeq,
+			count:   m.count,
+		}
+	}
+
+	seq := m.nextSeq + 1
+	newBucket := append(append([]pHashEntry[K, V]{}, bucket...), pHashEntry[K, V]{Key: key, Value: value, Seq: seq})
+
+	return &PersistentOrderedMap[K, V]{
+		bySeq:   pSeqOps[K, V]().insert(m.bySeq, seq, pentry[K, V]{Key: key, Value: value}),
+		byHash:  pHashOps[K, V]().insert(m.byHash, h, newBucket),
+		nextSeq: seq,
+		count:   m.count + 1,
+	}
+}
+
+// Delete returns a new map with key removed, leaving m unmodified. It
+// returns m itself if key is not present.
+func (m *PersistentOrderedMap[K, V]) Delete(key K) *PersistentOrderedMap[K, V] {
+	existing, ok := m.findSeq(key)
+	if !ok {
+		return m
+	}
+
+	h := phashKey(key)
+	bucket, _ := pHashOps[K, V]().get(m.byHash, h)
+
+	newBucket := make([]pHashEntry[K, V], 0, len(bucket))
+	for _, entry := range bucket {
+		if entry.Key != key {
+			newBucket = append(newBucket, entry)
+		}
+	}
+
+	newByHash := m.byHash
+	if len(newBucket) == 0 {
+		newByHash = pHashOps[K, V]().remove(m.byHash, h)
+	} else {
+		newByHash = pHashOps[K, V]().insert(m.byHash, h, newBucket)
+	}
+
+	return &PersistentOrderedMap[K, V]{
+		bySeq:   pSeqOps[K, V]().remove(m.bySeq, existing.Seq),
+		byHash:  newByHash,
+		nextSeq: m.nextSeq,
+		count:   m.count - 1,
+	}
+}
+
+// PersistentCursor is a position within a PersistentOrderedMap's
+// insertion order, obtained from First and advanced with Next.
+type PersistentCursor[K comparable, V any] struct {
+	stack []*pnode[int64, pentry[K, V]]
+}
+
+func (c *PersistentCursor[K, V]) pushLeft(t ptree[int64, pentry[K, V]]) {
+	for t.node != nil {
+		c.stack = append(c.stack, t.node)
+		t = t.node.left
+	}
+}
+
+// Key returns the key the cursor currently points at.
+func (c *PersistentCursor[K, V]) Key() K { return c.stack[len(c.stack)-1].value.Key }
+
+// Value returns the value the cursor currently points at.
+func (c *PersistentCursor[K, V]) Value() V { return c.stack[len(c.stack)-1].value.Value }
+
+// Next advances the cursor to the next element (in insertion order) and
+// returns it, or nil once there are no elements left.
+func (c *PersistentCursor[K, V]) Next() *PersistentCursor[K, V] {
+	top := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	c.pushLeft(top.right)
+	if len(c.stack) == 0 {
+		return nil
+	}
+	return c
+}
+
+// First returns a cursor positioned at the first element of m in
+// insertion order, or nil if m is empty.
+func (m *PersistentOrderedMap[K, V]) First() *PersistentCursor[K, V] {
+	c := &PersistentCursor[K, V]{}
+	c.pushLeft(m.bySeq)
+	if len(c.stack) == 0 {
+		return nil
+	}
+	return c
+}
+
+// Transient is a mutable builder for bulk-constructing a
+// PersistentOrderedMap: it mutates an ordinary OrderedMap in place,
+// amortizing the per-edit path-copying cost of Set/Delete until Freeze
+// produces an immutable snapshot.
+type Transient[K comparable, V any] struct {
+	m *OrderedMap[K, V]
+}
+
+// Transient returns a builder seeded with m's current contents.
+func (m *PersistentOrderedMap[K, V]) Transient() *Transient[K, V] {
+	t := &Transient[K, V]{m: New[K, V]()}
+	for c := m.First(); c != nil; c = c.Next() {
+		t.m.Set(c.Key(), c.Value())
+	}
+	return t
+}
+
+// Set sets the given value under the specified key and returns t for
+// chaining.
+func (t *Transient[K, V]) Set(key K, value V) *Transient[K, V] {
+	t.m.Set(key, value)
+	return t
+}
+
+// Delete deletes an item from the builder and returns t for chaining.
+func (t *Transient[K, V]) Delete(key K) *Transient[K, V] {
+	t.m.Delete(key)
+	return t
+}
+
+// Freeze returns an immutable PersistentOrderedMap snapshot of t's
+// current contents. t remains usable afterwards; further mutations to it
+// do not affect the returned snapshot.
+//
+// Unlike calling Set repeatedly on an empty PersistentOrderedMap, Freeze
+// builds both of the snapshot's trees directly from t's contents: the
+// insertion-order tree in O(n) time, since it is already sorted by
+// sequence number, and the hash tree after a single O(b log b) sort of
+// its b distinct hash buckets (one per key unless hashes collide).
+// Neither pays for the O(log n) path-copying and rebalancing a
+// Set-by-Set rebuild would repeat n times.
+func (t *Transient[K, V]) Freeze() *PersistentOrderedMap[K, V] {
+	type item struct {
+		key   K
+		value V
+		seq   int64
+	}
+
+	items := make([]item, 0, t.m.Len())
+	for c := t.m.First(); c != nil; c = c.Next() {
+		items = append(items, item{key: c.Key(), value: c.Value(), seq: int64(len(items) + 1)})
+	}
+
+	i := 0
+	bySeq := pbuildSorted[int64, pentry[K, V]](len(items), func() (int64, pentry[K, V]) {
+		it := items[i]
+		i++
+		return it.seq, pentry[K, V]{Key: it.key, Value: it.value}
+	})
+
+	buckets := make(map[uint64][]pHashEntry[K, V], len(items))
+	for _, it := range items {
+		h := phashKey(it.key)
+		buckets[h] = append(buckets[h], pHashEntry[K, V]{Key: it.key, Value: it.value, Seq: it.seq})
+	}
+	hashes := make([]uint64, 0, len(buckets))
+	for h := range buckets {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(a, b int) bool { return hashes[a] < hashes[b] })
+
+	j := 0
+	byHash := pbuildSorted[uint64, []pHashEntry[K, V]](len(hashes), func() (uint64, []pHashEntry[K, V]) {
+		h := hashes[j]
+		j++
+		return h, buckets[h]
+	})
+
+	var nextSeq int64
+	if n := len(items); n > 0 {
+		nextSeq = items[n-1].seq
+	}
+
+	return &PersistentOrderedMap[K, V]{
+		bySeq:   bySeq,
+		byHash:  byHash,
+		nextSeq: nextSeq,
+		count:   len(items),
+	}
+}
@@ -0,0 +1,256 @@
+package orderedmap
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func collectPersistent[K comparable, V any](m *PersistentOrderedMap[K, V]) ([]K, []V) {
+	var keys []K
+	var values []V
+	for c := m.First(); c != nil; c = c.Next() {
+		keys = append(keys, c.Key())
+		values = append(values, c.Value())
+	}
+	return keys, values
+}
+
+func TestPersistentSetGet(t *testing.T) {
+	m := NewPersistent[string, int]()
+	m = m.Set("a", 1)
+	m = m.Set("b", 2)
+
+	if val, ok := m.Get("a"); !ok || val != 1 {
+		t.Errorf("Get(a) = %v, %v; want 1, true", val, ok)
+	}
+	if _, ok := m.Get("missing"); ok {
+		t.Error("expected Get on missing key to return false.")
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+}
+
+func TestPersistentSetReturnsNewMap(t *testing.T) {
+	m1 := NewPersistent[string, int]()
+	m1 = m1.Set("a", 1)
+
+	m2 := m1.Set("b", 2)
+
+	if _, ok := m1.Get("b"); ok {
+		t.Error("expected original snapshot to be unaffected by Set on the new one.")
+	}
+	if val, ok := m2.Get("a"); !ok || val != 1 {
+		t.Error("expected new snapshot to retain the original's entries.")
+	}
+	if m1.Len() != 1 || m2.Len() != 2 {
+		t.Errorf("got Len() %d, %d; want 1, 2", m1.Len(), m2.Len())
+	}
+}
+
+func TestPersistentSetExistingKeyKeepsPosition(t *testing.T) {
+	m := NewPersistent[string, int]()
+	m = m.Set("a", 1)
+	m = m.Set("b", 2)
+	m = m.Set("c", 3)
+	m = m.Set("b", 20)
+
+	keys, values := collectPersistent(m)
+	wantKeys := []string{"a", "b", "c"}
+	wantValues := []int{1, 20, 3}
+
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("got keys %v, want %v", keys, wantKeys)
+	}
+	for i := range wantKeys {
+		if keys[i] != wantKeys[i] || values[i] != wantValues[i] {
+			t.Errorf("position %d = (%v,%v), want (%v,%v)", i, keys[i], values[i], wantKeys[i], wantValues[i])
+		}
+	}
+}
+
+func TestPersistentDeleteReturnsNewMap(t *testing.T) {
+	m1 := NewPersistent[string, int]()
+	m1 = m1.Set("a", 1)
+	m1 = m1.Set("b", 2)
+
+	m2 := m1.Delete("a")
+
+	if _, ok := m1.Get("a"); !ok {
+		t.Error("expected original snapshot to still contain the deleted key.")
+	}
+	if _, ok := m2.Get("a"); ok {
+		t.Error("expected new snapshot to no longer contain the deleted key.")
+	}
+	if m1.Len() != 2 || m2.Len() != 1 {
+		t.Errorf("got Len() %d, %d; want 2, 1", m1.Len(), m2.Len())
+	}
+}
+
+func TestPersistentDeleteMissingKeyIsNoop(t *testing.T) {
+	m := NewPersistent[string, int]()
+	m = m.Set("a", 1)
+
+	m2 := m.Delete("missing")
+	if m2 != m {
+		t.Error("expected Delete of a missing key to return the receiver unchanged.")
+	}
+}
+
+func TestPersistentIterationOrder(t *testing.T) {
+	m := NewPersistent[string, int]()
+	for i := 0; i < 100; i++ {
+		m = m.Set(strconv.Itoa(i), i)
+	}
+
+	keys, _ := collectPersistent(m)
+	if len(keys) != 100 {
+		t.Fatalf("got %d keys, want 100", len(keys))
+	}
+	for i, key := range keys {
+		if key != strconv.Itoa(i) {
+			t.Errorf("key at position %d = %s, want %s", i, key, strconv.Itoa(i))
+		}
+	}
+}
+
+// TestPersistentRandomizedAgainstReference inserts and deletes random
+// keys, then checks the persistent map against a plain Go map and a
+// separately tracked insertion order once all steps are done.
+func TestPersistentRandomizedAgainstReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	m := NewPersistent[int, int]()
+	reference := make(map[int]int)
+	var order []int
+
+	for i := 0; i < 2000; i++ {
+		key := rng.Intn(200)
+
+		if rng.Intn(3) == 0 {
+			m = m.Delete(key)
+			if _, existed := reference[key]; existed {
+				delete(reference, key)
+				for j, k := range order {
+					if k == key {
+						order = append(order[:j], order[j+1:]...)
+						break
+					}
+				}
+			}
+			continue
+		}
+
+		value := rng.Intn(1_000_000)
+		if _, existed := reference[key]; !existed {
+			order = append(order, key)
+		}
+		reference[key] = value
+		m = m.Set(key, value)
+	}
+
+	if m.Len() != len(reference) {
+		t.Fatalf("Len() = %d, want %d", m.Len(), len(reference))
+	}
+
+	keys, values := collectPersistent(m)
+	if len(keys) != len(order) {
+		t.Fatalf("got %d keys from iteration, want %d", len(keys), len(order))
+	}
+	for i, key := range order {
+		if keys[i] != key {
+			t.Fatalf("key at position %d = %d, want %d", i, keys[i], key)
+		}
+		if values[i] != reference[key] {
+			t.Fatalf("value for key %d = %d, want %d", key, values[i], reference[key])
+		}
+	}
+
+	for key, want := range reference {
+		got, ok := m.Get(key)
+		if !ok || got != want {
+			t.Fatalf("Get(%d) = %d, %v; want %d, true", key, got, ok, want)
+		}
+	}
+}
+
+func TestPersistentTransient(t *testing.T) {
+	tr := NewPersistent[string, int]().Transient()
+	tr.Set("a", 1).Set("b", 2).Set("c", 3).Delete("b")
+
+	snapshot := tr.Freeze()
+
+	keys, values := collectPersistent(snapshot)
+	wantKeys := []string{"a", "c"}
+	wantValues := []int{1, 3}
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("got keys %v, want %v", keys, wantKeys)
+	}
+	for i := range wantKeys {
+		if keys[i] != wantKeys[i] || values[i] != wantValues[i] {
+			t.Errorf("position %d = (%v,%v), want (%v,%v)", i, keys[i], values[i], wantKeys[i], wantValues[i])
+		}
+	}
+
+	// Further mutation of the builder must not affect the frozen snapshot.
+	tr.Set("d", 4)
+	if _, ok := snapshot.Get("d"); ok {
+		t.Error("expected frozen snapshot to be unaffected by further Transient mutation.")
+	}
+}
+
+// TestPersistentTransientFreezeLarge builds a large snapshot through
+// Transient.Freeze's bottom-up construction, then exercises ordinary
+// Set/Delete on top of it: if the built trees didn't satisfy the
+// red-black invariants, the rotations those perform would eventually
+// misbehave.
+func TestPersistentTransientFreezeLarge(t *testing.T) {
+	tr := NewPersistent[int, int]().Transient()
+	for i := 0; i < 500; i++ {
+		tr.Set(i, i*10)
+	}
+
+	snapshot := tr.Freeze()
+	if snapshot.Len() != 500 {
+		t.Fatalf("Len() = %d, want 500", snapshot.Len())
+	}
+
+	keys, values := collectPersistent(snapshot)
+	for i := 0; i < 500; i++ {
+		if keys[i] != i || values[i] != i*10 {
+			t.Fatalf("position %d = (%d,%d), want (%d,%d)", i, keys[i], values[i], i, i*10)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		if val, ok := snapshot.Get(i); !ok || val != i*10 {
+			t.Fatalf("Get(%d) = %d, %v; want %d, true", i, val, ok, i*10)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	m := snapshot
+	reference := make(map[int]int, 500)
+	for i := 0; i < 500; i++ {
+		reference[i] = i * 10
+	}
+	for i := 0; i < 1000; i++ {
+		key := rng.Intn(500)
+		if rng.Intn(2) == 0 {
+			m = m.Delete(key)
+			delete(reference, key)
+		} else {
+			m = m.Set(key, key*100)
+			reference[key] = key * 100
+		}
+	}
+
+	if m.Len() != len(reference) {
+		t.Fatalf("after Set/Delete: Len() = %d, want %d", m.Len(), len(reference))
+	}
+	for key, want := range reference {
+		if got, ok := m.Get(key); !ok || got != want {
+			t.Fatalf("after Set/Delete: Get(%d) = %d, %v; want %d, true", key, got, ok, want)
+		}
+	}
+}